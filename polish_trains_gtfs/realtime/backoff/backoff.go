@@ -5,6 +5,7 @@ package backoff
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -16,21 +17,31 @@ const (
 	Retry
 )
 
+// Backoff is safe for concurrent use: StartRun/EndRun are called from the
+// owning loop, but EndRun can also run from an HTTP handler goroutine (a
+// forced /admin/refresh) concurrently with Wait reading nextRun in that
+// loop, so lastRun, nextRun and Failures are guarded by mu.
 type Backoff struct {
 	Period                 time.Duration
 	ExponentialBackoffBase time.Duration
 	Failures               uint
 	MaxBackoffExponent     uint
 
+	mu      sync.Mutex
 	lastRun time.Time
 	nextRun time.Time
 }
 
 func (b *Backoff) StartRun() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.lastRun = time.Now()
 }
 
 func (b *Backoff) EndRun(status Status) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	switch status {
 	case Success:
 		b.Failures = 0
@@ -59,7 +70,10 @@ func (b *Backoff) EndRun(status Status) time.Time {
 }
 
 func (b *Backoff) Wait() {
-	time.Sleep(time.Until(b.nextRun))
+	b.mu.Lock()
+	nextRun := b.nextRun
+	b.mu.Unlock()
+	time.Sleep(time.Until(nextRun))
 }
 
 func (b *Backoff) getBackoffBase() time.Duration {
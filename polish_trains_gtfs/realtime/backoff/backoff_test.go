@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_EndRun_ExponentialProgression(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures uint
+		want     time.Duration
+	}{
+		{"first failure", 0, 30 * time.Second},
+		{"second failure", 1, 60 * time.Second},
+		{"third failure", 2, 120 * time.Second},
+		{"capped at MaxBackoffExponent", 10, 64 * 30 * time.Second}, // 1<<6
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Backoff{
+				Period:                 time.Minute,
+				ExponentialBackoffBase: 30 * time.Second,
+				MaxBackoffExponent:     6,
+				Failures:               tt.failures,
+			}
+			b.lastRun = time.Time{}
+
+			next := b.EndRun(Failure)
+			if got := next.Sub(b.lastRun); got != tt.want {
+				t.Errorf("EndRun(Failure) backoff = %s, want %s", got, tt.want)
+			}
+			if b.Failures != tt.failures+1 {
+				t.Errorf("Failures = %d, want %d", b.Failures, tt.failures+1)
+			}
+		})
+	}
+}
+
+func TestBackoff_EndRun_SuccessResetsFailures(t *testing.T) {
+	b := Backoff{Period: time.Minute, Failures: 5}
+	b.lastRun = time.Time{}
+
+	next := b.EndRun(Success)
+	if b.Failures != 0 {
+		t.Errorf("Failures = %d, want 0", b.Failures)
+	}
+	if got := next.Sub(b.lastRun); got != time.Minute {
+		t.Errorf("next run offset = %s, want %s", got, time.Minute)
+	}
+}
+
+func TestBackoff_EndRun_RetryUsesBackoffBaseOnce(t *testing.T) {
+	b := Backoff{Period: time.Minute, ExponentialBackoffBase: 10 * time.Second, Failures: 3}
+	b.lastRun = time.Time{}
+
+	next := b.EndRun(Retry)
+	if b.Failures != 1 {
+		t.Errorf("Failures = %d, want 1", b.Failures)
+	}
+	if got := next.Sub(b.lastRun); got != 10*time.Second {
+		t.Errorf("next run offset = %s, want %s", got, 10*time.Second)
+	}
+}
+
+func TestBackoff_EndRun_InvalidStatusPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for an invalid Status")
+		}
+	}()
+	(&Backoff{}).EndRun(Status(99))
+}
@@ -0,0 +1,43 @@
+//go:build chaos
+
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
+)
+
+var (
+	flagSimulateFailures   = flag.Bool("simulate-failures", false, "chaos-test mode: wrap every client in a fault-injecting Doer (only available in -tags chaos builds)")
+	flagSimulateRate429    = flag.Float64("simulate-rate-429", 0, "probability [0, 1] of injecting a synthetic 429 per request")
+	flagSimulateRate500    = flag.Float64("simulate-rate-500", 0, "probability [0, 1] of injecting a synthetic 500 per request")
+	flagSimulateRate503    = flag.Float64("simulate-rate-503", 0, "probability [0, 1] of injecting a synthetic 503 per request")
+	flagSimulateDropRate   = flag.Float64("simulate-drop-rate", 0, "probability [0, 1] of truncating a response body mid-read")
+	flagSimulateLatency    = flag.Duration("simulate-latency", 0, "upper bound of random extra latency injected per request")
+	flagSimulateRetryAfter = flag.Duration("simulate-retry-after", 0, "Retry-After duration to set on injected 429s")
+)
+
+// wrapChaosDoer wraps doer in a [http2.FaultInjectingDoer] when
+// --simulate-failures is set. Only compiled into -tags chaos builds.
+func wrapChaosDoer(doer http2.Doer) http2.Doer {
+	if !*flagSimulateFailures {
+		return doer
+	}
+
+	return &http2.FaultInjectingDoer{
+		Parent: doer,
+		Profile: http2.FaultProfile{
+			Rate429:         *flagSimulateRate429,
+			Rate500:         *flagSimulateRate500,
+			Rate503:         *flagSimulateRate503,
+			DropRate:        *flagSimulateDropRate,
+			MaxExtraLatency: *flagSimulateLatency,
+			RetryAfter:      *flagSimulateRetryAfter,
+		},
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !chaos
+
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package main
+
+import "github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
+
+// wrapChaosDoer is a no-op outside of -tags chaos builds: --simulate-failures
+// doesn't exist, so there's nothing to wrap.
+func wrapChaosDoer(doer http2.Doer) http2.Doer { return doer }
@@ -13,13 +13,16 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/alternative"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/backoff"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/match"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/output"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/serve"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/client"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
@@ -32,22 +35,44 @@ var (
 	flagAlternative = flag.Duration("alternative", 20*time.Minute, "when non-zero, fetch fresh schedules from API")
 	flagGTFS        = flag.String("gtfs", "polish_trains.zip", "path to GTFS Schedule feed")
 	flagLoop        = flag.Duration("loop", 0, "when non-zero, update the feed continuously with the given period")
-	flagOutput      = flag.String("output", "polish_trains.pb", "path to output .pb file")
+	flagOutput      = flag.String("output", "polish_trains.pb", "where to publish the output .pb file: a plain path, a file:// URL, or s3://bucket/key (gs:// and azblob:// are recognized but not implemented yet)")
 	flagReadable    = flag.Bool("readable", false, "dump output in human-readable format")
+	flagRetryMax    = flag.Uint("retry-max-attempts", 4, "max attempts (including the first) for a single retryable HTTP request before giving up; 0 or 1 disables retries")
+	flagRetryBase   = flag.Duration("retry-base", 500*time.Millisecond, "base delay for exponential backoff with full jitter between retries")
+	flagRetryCap    = flag.Duration("retry-cap", 30*time.Second, "upper bound on any single inter-retry backoff delay; a Retry-After header from the server is honored as is")
+	flagServe       = flag.String("serve", "", "when non-empty, also serve the feed live over HTTP on this address, e.g. :8080")
 	flagVerbose     = flag.Bool("verbose", false, "show DEBUG logging")
 	flagVpn         = flag.String("vpn", "", "when non-empty, route all traffic through VPN(s) set-up with a WireGuard config file or directory with such files")
 )
 
-var jsonOutput = ""
+// probeURL is hit with a lightweight HEAD request to detect dead WireGuard
+// peers; see [client.Pool.StartProbing].
+const probeURL = "https://pdp-api.plk-sa.pl/api/v1/schedules/shortened"
+
+var outputSink output.Sink
+var outputKey, jsonOutputKey string
 var altLookupReloader alternative.LookupReloader = alternative.NopLookupReloader{}
 var clientPool *client.Pool
+var httpServer *serve.Server
+var runMu sync.Mutex
+var lastSuccess time.Time
+var lastStats match.Stats
 
 func main() {
 	flag.Parse()
 	if *flagVerbose {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
 	}
-	initJsonOutput()
+	initOutput()
+	// Every http2.GetJSON call in the process falls back to this when it
+	// isn't passed an explicit policy, so the paginated run-path fetches
+	// (source.FetchOperations, source.FetchDisruptions) get retries too,
+	// not just call sites that remember to thread a policy through by hand.
+	http2.DefaultRetry = http2.RetryPolicy{
+		MaxAttempts: *flagRetryMax,
+		BaseDelay:   *flagRetryBase,
+		MaxDelay:    *flagRetryCap,
+	}
 
 	apikey, err := secret.FromEnvironment("PKP_PLK_APIKEY")
 	if err != nil {
@@ -55,6 +80,9 @@ func main() {
 	}
 	initClientPool(apikey)
 	defer clientPool.Close()
+	if *flagVpn != "" {
+		clientPool.StartProbing(context.Background(), probeURL, 0)
+	}
 
 	slog.Info("Loading static schedules")
 	static, err := schedules.LoadGTFSFromPath(*flagGTFS)
@@ -70,11 +98,19 @@ func main() {
 	}
 
 	if *flagLoop == 0 {
-		totalFacts, stats, err := run(static)
-		if err != nil {
+		if *flagServe != "" {
+			startHTTPServer(func() error { return runAndReport(static) })
+		}
+		if err := runAndReport(static); err != nil {
 			log.Fatal(err)
 		}
-		slog.Info("Feed updated successfully", "facts", totalFacts, "stats", stats)
+		if *flagServe != "" {
+			// --loop 0 --serve is a long-running service, not a write-file-
+			// then-exit tool: keep the process alive so the HTTP server
+			// startHTTPServer launched in the background actually serves.
+			// startHTTPServer's goroutine calls log.Fatal if it ever stops.
+			select {}
+		}
 	} else {
 		b := backoff.Backoff{
 			Period:                 *flagLoop,
@@ -82,24 +118,94 @@ func main() {
 			MaxBackoffExponent:     6,
 		}
 
+		if *flagServe != "" {
+			startHTTPServer(func() error { return runLoopIteration(static, &b) })
+		}
+
 		for {
 			b.Wait()
-			b.StartRun()
-			totalFacts, stats, err := run(static)
-			if err != nil && canBackoff(err) {
-				clientPool.BackoffLast()
-				nextTry := b.EndRun(backoff.Failure)
-				slog.Error("Feed update failure", "error", err, "next_try", nextTry)
-			} else if err != nil {
+			if err := runLoopIteration(static, &b); err != nil {
 				log.Fatal(err)
-			} else {
-				b.EndRun(backoff.Success)
-				slog.Info("Feed updated successfully", "facts", totalFacts, "stats", stats)
 			}
 		}
 	}
 }
 
+// runLoopIteration runs a single update in --loop mode, feeding the result
+// back into b. It is also what the /admin/refresh endpoint calls directly,
+// which is how a forced refresh bypasses b.Wait.
+func runLoopIteration(static *schedules.Package, b *backoff.Backoff) error {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	b.StartRun()
+	totalFacts, stats, err := run(static)
+	if err != nil && canBackoff(err) {
+		clientPool.BackoffLast()
+		nextTry := b.EndRun(backoff.Failure)
+		slog.Error("Feed update failure", "error", err, "next_try", nextTry)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	b.EndRun(backoff.Success)
+	onRunSuccess(totalFacts, stats)
+	return nil
+}
+
+// runAndReport runs a single update outside of --loop mode (a one-shot
+// invocation, or a forced refresh while only --serve is in use).
+func runAndReport(static *schedules.Package) error {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	totalFacts, stats, err := run(static)
+	if err != nil {
+		return err
+	}
+	onRunSuccess(totalFacts, stats)
+	return nil
+}
+
+func onRunSuccess(totalFacts int, stats match.Stats) {
+	lastSuccess = time.Now()
+	lastStats = stats
+	slog.Info("Feed updated successfully", "facts", totalFacts, "stats", stats)
+}
+
+// startHTTPServer brings up the --serve HTTP server in the background,
+// keeping the in-memory feed fresh and wiring /admin/refresh to refresh.
+func startHTTPServer(refresh func() error) {
+	httpServer = serve.New()
+	httpServer.Refresh = refresh
+	httpServer.Status = buildStatus
+
+	go func() {
+		slog.Info("Starting HTTP server", "addr", *flagServe)
+		if err := http.ListenAndServe(*flagServe, httpServer.Handler()); err != nil {
+			log.Fatal(err)
+		}
+	}()
+}
+
+func buildStatus() serve.Status {
+	poolStatus := clientPool.Status()
+	active := ""
+	for _, s := range poolStatus {
+		if s.LastSelected {
+			active = s.Label
+		}
+	}
+
+	return serve.Status{
+		LastSuccess: lastSuccess,
+		Stats:       lastStats,
+		Pool:        poolStatus,
+		ActivePeer:  active,
+	}
+}
+
 func run(static *schedules.Package) (int, match.Stats, error) {
 	client := clientPool.Select()
 
@@ -159,21 +265,62 @@ func fetchUpdates(static *schedules.Package, client *client.Client) (*fact.Conta
 }
 
 func writeOutput(facts *fact.Container) error {
+	dumpGTFS := func(path string) error { return facts.DumpGTFSFile(path, *flagReadable) }
+	dumpJSON := func(path string) error { return facts.DumpJSONFile(path, *flagReadable) }
+
 	slog.Debug("Dumping GTFS-Realtime")
-	err := facts.DumpGTFSFile(*flagOutput, *flagReadable)
+	pb, err := dumpToBytes(dumpGTFS)
 	if err != nil {
-		return fmt.Errorf("%s: %w", *flagOutput, err)
+		return fmt.Errorf("%s: %w", outputKey, err)
 	}
 
 	slog.Debug("Dumping JSON")
-	err = facts.DumpJSONFile(jsonOutput, *flagReadable)
+	j, err := dumpToBytes(dumpJSON)
 	if err != nil {
-		return fmt.Errorf("%s: %w", jsonOutput, err)
+		return fmt.Errorf("%s: %w", jsonOutputKey, err)
+	}
+
+	slog.Debug("Publishing feed", "sink", fmt.Sprintf("%T", outputSink))
+	if err := outputSink.Put(context.Background(), outputKey, pb, "application/x-protobuf"); err != nil {
+		return fmt.Errorf("%s: %w", outputKey, err)
+	}
+	if err := outputSink.Put(context.Background(), jsonOutputKey, j, "application/json"); err != nil {
+		return fmt.Errorf("%s: %w", jsonOutputKey, err)
+	}
+
+	if httpServer != nil {
+		if *flagAlerts {
+			err = httpServer.SetAlerts(facts.Timestamp, dumpGTFS, dumpJSON)
+		} else {
+			err = httpServer.SetFeed(facts.Timestamp, dumpGTFS, dumpJSON)
+		}
+		if err != nil {
+			return fmt.Errorf("serving feed live: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// dumpToBytes renders a dump-to-path function (like
+// [fact.Container.DumpGTFSFile]) into an in-memory []byte, so it can be
+// handed to an [output.Sink] instead of being written straight to a final
+// path.
+func dumpToBytes(dump func(path string) error) ([]byte, error) {
+	f, err := os.CreateTemp("", "ptg-realtime-output-*")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	if err := dump(path); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
 func canBackoff(err error) bool {
 	// Only backoff on 429, 500 i 503 HTTP errors
 	if httpErr, ok := err.(*http2.Error); ok {
@@ -185,16 +332,28 @@ func canBackoff(err error) bool {
 	return false
 }
 
-func initJsonOutput() {
-	dir, name := filepath.Split(*flagOutput)
+// initOutput resolves --output into a [output.Sink] plus the pb/json keys
+// to Put within it, accepting plain paths, file:// URLs and s3://bucket/key
+// destinations alike.
+func initOutput() {
+	sink, key, err := output.ParseDestination(*flagOutput)
+	if err != nil {
+		log.Fatal(err)
+	}
+	outputSink = sink
+	outputKey = key
+	jsonOutputKey = withExtension(key, "json")
+}
+
+func withExtension(key, ext string) string {
+	dir, name := filepath.Split(key)
 	parts := strings.Split(name, ".")
 	if len(parts) <= 1 {
-		parts = append(parts, "json")
+		parts = append(parts, ext)
 	} else {
-		parts[len(parts)-1] = "json"
+		parts[len(parts)-1] = ext
 	}
-	name = strings.Join(parts, ".")
-	jsonOutput = dir + name
+	return dir + strings.Join(parts, ".")
 }
 
 func initClientPool(apikey string) {
@@ -207,7 +366,8 @@ func initClientPool(apikey string) {
 	if *flagVpn == "" {
 		clients = append(clients, &client.Client{
 			Key:       apikey,
-			Doer:      http.DefaultClient,
+			Label:     "direct",
+			Doer:      wrapChaosDoer(http.DefaultClient),
 			RateLimit: rateLimit,
 		})
 	} else if !isDir(*flagVpn) {
@@ -223,7 +383,8 @@ func initClientPool(apikey string) {
 
 		clients = append(clients, &client.Client{
 			Key:       apikey,
-			Doer:      c,
+			Label:     filepath.Base(*flagVpn),
+			Doer:      wrapChaosDoer(c),
 			Closer:    closer,
 			RateLimit: rateLimit,
 		})
@@ -251,7 +412,8 @@ func initClientPool(apikey string) {
 
 			clients = append(clients, &client.Client{
 				Key:       apikey,
-				Doer:      c,
+				Label:     file.Name(),
+				Doer:      wrapChaosDoer(c),
 				Closer:    closer,
 				RateLimit: rateLimit,
 			})
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Sink uploads feed files to an S3 (or S3-compatible) bucket.
+type S3Sink struct {
+	Client *s3.Client
+	Bucket string
+	Prefix string // prepended to every key, e.g. "feeds/"
+}
+
+// NewS3Sink builds an [S3Sink] for bucket using the default AWS credential
+// chain (environment, shared config, instance role, ...).
+func NewS3Sink(ctx context.Context, bucket, prefix string) (*S3Sink, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("output: loading AWS config: %w", err)
+	}
+	return &S3Sink{Client: s3.NewFromConfig(cfg), Bucket: bucket, Prefix: prefix}, nil
+}
+
+// Put uploads body, setting Content-Type and Cache-Control: no-cache so
+// consumers pulling straight from the bucket always revalidate. No ETag is
+// set explicitly - PutObjectInput has no such field, since S3 computes and
+// returns its own ETag from the object's content hash on every PUT. That's
+// exactly the "short-lived" property we'd want from a hand-set one anyway:
+// it changes every time this process uploads a new feed.
+func (s *S3Sink) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       aws.String(s.Bucket),
+		Key:          aws.String(s.Prefix + key),
+		Body:         bytes.NewReader(body),
+		ContentType:  aws.String(contentType),
+		CacheControl: aws.String("no-cache"),
+	})
+	if err != nil {
+		return fmt.Errorf("output: s3://%s/%s%s: %w", s.Bucket, s.Prefix, key, err)
+	}
+	return nil
+}
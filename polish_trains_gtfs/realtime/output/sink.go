@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+// Package output lets the "produce → publish" step write a feed to
+// destinations other than a local path - most notably an S3-style bucket,
+// so consumers can pull the feed straight from a public bucket / CDN
+// instead of a cron-plus-"aws s3 cp" wrapper doing it after the fact.
+package output
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sink is the pluggable destination the "produce → publish" step uploads a
+// feed file to. [LocalFileSink] covers plain paths and file:// URLs (the
+// historical behavior); [S3Sink] covers s3:// destinations. Future backends
+// (Azure blob, GCS, a plain HTTP PUT) can implement the same interface
+// without touching main.
+type Sink interface {
+	// Put uploads body under key with the given content type. Implementations
+	// should make the write atomic where the backend allows it.
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// ParseDestination turns an --output-style destination into a [Sink] and
+// the key to Put within it. Plain paths and file:// URLs resolve to a
+// [LocalFileSink] rooted at the containing directory; s3://bucket/key
+// resolves to an [S3Sink].
+func ParseDestination(dest string) (Sink, string, error) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// len(Scheme) == 1 catches a Windows drive letter like "C:\..."
+		// parsing as a URL with scheme "c" - never what's meant here.
+		return &LocalFileSink{Dir: filepath.Dir(dest)}, filepath.Base(dest), nil
+	}
+
+	switch u.Scheme {
+	case "file":
+		return &LocalFileSink{Dir: filepath.Dir(u.Path)}, filepath.Base(u.Path), nil
+	case "s3":
+		sink, err := NewS3Sink(context.Background(), u.Host, "")
+		if err != nil {
+			return nil, "", err
+		}
+		return sink, strings.TrimPrefix(u.Path, "/"), nil
+	case "gs", "azblob":
+		// Recognized so a typo doesn't read the same as "not implemented
+		// yet" below: the Sink interface was designed for exactly these
+		// (see the [Sink] doc), but only S3Sink exists so far.
+		return nil, "", fmt.Errorf("output: %q destinations aren't implemented yet; only file://, s3:// and plain paths are", u.Scheme)
+	default:
+		return nil, "", fmt.Errorf("output: unsupported destination scheme %q", u.Scheme)
+	}
+}
+
+// LocalFileSink writes feed files to a directory on the local filesystem,
+// the historical --output behavior.
+type LocalFileSink struct {
+	Dir string
+}
+
+func (s *LocalFileSink) Put(_ context.Context, key string, body []byte, _ string) error {
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-"+filepath.Base(key)+"-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(s.Dir, key))
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+// Package serve turns a produced GTFS-Realtime feed into a long-running
+// HTTP service: the most recent feed (and, optionally, the most recent
+// alerts) are kept in memory and exposed live, alongside a couple of
+// /admin endpoints an operator can use to poke the process without
+// SIGHUP tricks.
+package serve
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the payload returned by GET /admin/status. Callers populate it
+// from whatever run/pool state main keeps track of.
+type Status struct {
+	LastSuccess time.Time `json:"last_success"`
+	Stats       any       `json:"stats,omitempty"`
+	Pool        any       `json:"pool,omitempty"`
+	ActivePeer  string    `json:"active_peer,omitempty"`
+}
+
+// feed is an immutable snapshot of a marshalled [fact.Container], ready to
+// be served as-is.
+type feed struct {
+	pb, json []byte
+	modified time.Time
+	etagBase string // combined with the representation (pb/json) to form a full ETag
+}
+
+// Server keeps the most recently produced feed(s) in memory and serves them
+// over HTTP. The zero value is not servable until SetFeed has been called
+// at least once; Refresh and Status are optional hooks wired up by main.
+type Server struct {
+	// Refresh forces an immediate out-of-cycle run, bypassing any
+	// [backoff.Backoff] wait. Called by POST /admin/refresh.
+	Refresh func() error
+
+	// Status reports the current run/pool state for GET /admin/status.
+	Status func() Status
+
+	main   atomic.Pointer[feed]
+	alerts atomic.Pointer[feed]
+}
+
+// New creates an empty [Server]; use SetFeed/SetAlerts to populate it.
+func New() *Server {
+	return &Server{}
+}
+
+// SetFeed stores the latest trip update facts, making them available at
+// /feed and /feed.pb / /feed.json. dumpGTFS and dumpJSON are expected to
+// behave like [fact.Container.DumpGTFSFile] / [fact.Container.DumpJSONFile]
+// writing to path, but serve needs the bytes rather than a file on disk.
+func (s *Server) SetFeed(modified time.Time, dumpGTFS, dumpJSON func(path string) error) error {
+	f, err := buildFeed(modified, dumpGTFS, dumpJSON)
+	if err != nil {
+		return err
+	}
+	s.main.Store(f)
+	return nil
+}
+
+// SetAlerts stores the latest alert facts, making them available at
+// /alerts and /alerts.pb.
+func (s *Server) SetAlerts(modified time.Time, dumpGTFS, dumpJSON func(path string) error) error {
+	f, err := buildFeed(modified, dumpGTFS, dumpJSON)
+	if err != nil {
+		return err
+	}
+	s.alerts.Store(f)
+	return nil
+}
+
+func buildFeed(modified time.Time, dumpGTFS, dumpJSON func(path string) error) (*feed, error) {
+	pb, err := dumpToBytes(dumpGTFS)
+	if err != nil {
+		return nil, fmt.Errorf("serve: marshalling protobuf: %w", err)
+	}
+
+	j, err := dumpToBytes(dumpJSON)
+	if err != nil {
+		return nil, fmt.Errorf("serve: marshalling json: %w", err)
+	}
+
+	return &feed{
+		pb:       pb,
+		json:     j,
+		modified: modified,
+		etagBase: strconv.FormatInt(modified.UnixNano(), 10),
+	}, nil
+}
+
+func dumpToBytes(dump func(path string) error) ([]byte, error) {
+	f, err := os.CreateTemp("", "ptg-realtime-serve-*")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	if err := dump(path); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// Handler returns the http.Handler exposing /feed.pb, /feed.json,
+// /alerts.pb, /admin/refresh and /admin/status.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /feed", s.serveNegotiated(&s.main))
+	mux.HandleFunc("GET /feed.pb", s.serveFixed(&s.main, false))
+	mux.HandleFunc("GET /feed.json", s.serveFixed(&s.main, true))
+	mux.HandleFunc("GET /alerts", s.serveNegotiated(&s.alerts))
+	mux.HandleFunc("GET /alerts.pb", s.serveFixed(&s.alerts, false))
+	mux.HandleFunc("GET /alerts.json", s.serveFixed(&s.alerts, true))
+	mux.HandleFunc("POST /admin/refresh", s.serveRefresh)
+	mux.HandleFunc("GET /admin/status", s.serveStatus)
+	return mux
+}
+
+func (s *Server) serveFixed(slot *atomic.Pointer[feed], asJSON bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f := slot.Load()
+		if f == nil {
+			http.Error(w, "feed not ready yet", http.StatusServiceUnavailable)
+			return
+		}
+		// Representation is fixed by the path, not the Accept header, so
+		// only Accept-Encoding (the gzip decision below) varies the response.
+		serveFeed(w, r, f, asJSON, "Accept-Encoding")
+	}
+}
+
+func (s *Server) serveNegotiated(slot *atomic.Pointer[feed]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f := slot.Load()
+		if f == nil {
+			http.Error(w, "feed not ready yet", http.StatusServiceUnavailable)
+			return
+		}
+		serveFeed(w, r, f, wantsJSON(r), "Accept, Accept-Encoding")
+	}
+}
+
+func wantsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "application/x-protobuf")
+}
+
+func serveFeed(w http.ResponseWriter, r *http.Request, f *feed, asJSON bool, vary string) {
+	body := f.pb
+	contentType := "application/x-protobuf"
+	rep := "pb"
+	if asJSON {
+		body = f.json
+		contentType = "application/json"
+		rep = "json"
+	}
+	// The representation is folded into the ETag - a cache that stores both
+	// the pb and json bodies under the same URL (/feed, /alerts) must be
+	// able to tell them apart.
+	etag := fmt.Sprintf(`"%s-%s"`, f.etagBase, rep)
+
+	w.Header().Set("Vary", vary)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Last-Modified", f.modified.UTC().Format(http.TimeFormat))
+	w.Header().Set("ETag", etag)
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		gz.Write(body)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
+
+func (s *Server) serveRefresh(w http.ResponseWriter, r *http.Request) {
+	if s.Refresh == nil {
+		http.Error(w, "refresh not supported", http.StatusNotImplemented)
+		return
+	}
+
+	if err := s.Refresh(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	var status Status
+	if s.Status != nil {
+		status = s.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package serve
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := New()
+	dumpPB := func(path string) error { return os.WriteFile(path, []byte("pb-bytes"), 0o644) }
+	dumpJSON := func(path string) error { return os.WriteFile(path, []byte(`{"json":true}`), 0o644) }
+	if err := s.SetFeed(time.Unix(1000, 0), dumpPB, dumpJSON); err != nil {
+		t.Fatalf("SetFeed: %v", err)
+	}
+	return s
+}
+
+func TestServeFeed_NegotiatedSetsVaryOnAcceptAndEncoding(t *testing.T) {
+	s := newTestServer(t)
+	req := httptest.NewRequest("GET", "/feed", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+
+	if got := w.Header().Get("Vary"); got != "Accept, Accept-Encoding" {
+		t.Errorf("Vary = %q, want %q", got, "Accept, Accept-Encoding")
+	}
+}
+
+func TestServeFeed_FixedSetsVaryOnEncodingOnly(t *testing.T) {
+	s := newTestServer(t)
+	req := httptest.NewRequest("GET", "/feed.pb", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+
+	if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want %q", got, "Accept-Encoding")
+	}
+}
+
+func TestServeFeed_ETagDiffersByRepresentation(t *testing.T) {
+	s := newTestServer(t)
+
+	reqPB := httptest.NewRequest("GET", "/feed.pb", nil)
+	wPB := httptest.NewRecorder()
+	s.Handler().ServeHTTP(wPB, reqPB)
+
+	reqJSON := httptest.NewRequest("GET", "/feed.json", nil)
+	wJSON := httptest.NewRecorder()
+	s.Handler().ServeHTTP(wJSON, reqJSON)
+
+	pbETag, jsonETag := wPB.Header().Get("ETag"), wJSON.Header().Get("ETag")
+	if pbETag == "" || jsonETag == "" {
+		t.Fatal("expected both responses to carry an ETag")
+	}
+	if pbETag == jsonETag {
+		t.Fatalf("pb and json ETags must differ, both got %q", pbETag)
+	}
+}
+
+func TestServeFeed_IfNoneMatchMustMatchRepresentation(t *testing.T) {
+	s := newTestServer(t)
+
+	reqPB := httptest.NewRequest("GET", "/feed.pb", nil)
+	wPB := httptest.NewRecorder()
+	s.Handler().ServeHTTP(wPB, reqPB)
+	pbETag := wPB.Header().Get("ETag")
+
+	// The pb ETag must not satisfy a conditional GET for the json
+	// representation, or a shared cache could hand back the wrong body.
+	req := httptest.NewRequest("GET", "/feed.json", nil)
+	req.Header.Set("If-None-Match", pbETag)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+
+	if w.Code == 304 {
+		t.Fatal("pb's ETag incorrectly satisfied a conditional GET for json")
+	}
+}
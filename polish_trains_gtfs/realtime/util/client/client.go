@@ -4,9 +4,9 @@
 package client
 
 import (
-	"log/slog"
 	"math/rand/v2"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
@@ -14,12 +14,37 @@ import (
 
 const PoolClientBackoff = 30 * time.Minute
 
+// scoreWindow is how many recent requests a Client's health score is
+// computed over.
+const scoreWindow = 50
+
+// explorationEpsilon is the probability Pool.Select ignores health scores
+// and picks a candidate at random, so that a punished client is
+// periodically retried instead of being starved forever.
+const explorationEpsilon = 0.1
+
+// outcome is one scored request, kept in a [Client]'s rolling window.
+type outcome struct {
+	score   float64 // 1 for 2xx/3xx, 0.3 for non-429 4xx, 0 for 429/5xx/network error
+	latency time.Duration
+	bytes   int64
+}
+
 type Client struct {
 	Key       string
+	Label     string // human-readable identifier, e.g. the WireGuard peer name; used for introspection
 	Closer    func()
 	Doer      http2.Doer
 	RateLimit time.Duration
 	nextRun   time.Time
+
+	mu        sync.Mutex
+	window    [scoreWindow]outcome
+	cursor    int
+	filled    int
+	successes uint64
+	clientErr uint64
+	serverErr uint64
 }
 
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
@@ -31,7 +56,89 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 		c.nextRun = time.Now().Add(c.RateLimit)
 	}
 
-	return c.Doer.Do(req)
+	start := time.Now()
+	resp, err := c.Doer.Do(req)
+	c.record(resp, err, time.Since(start))
+	return resp, err
+}
+
+// record folds one request's outcome into the client's rolling health
+// window, classifying it the same way [main.canBackoff] classifies errors:
+// 2xx/3xx is healthy, 4xx is a mild penalty (usually our fault, not the
+// peer's), 5xx/429/network errors are scored as unhealthy.
+func (c *Client) record(resp *http.Response, err error, latency time.Duration) {
+	o := outcome{latency: latency}
+
+	// bucket picks which counter this outcome falls into; the increment
+	// itself happens below, under c.mu, alongside the rest of the state
+	// Counters() reads under the same lock.
+	var bucket *uint64
+	switch {
+	case err != nil:
+		o.score = 0
+		bucket = &c.serverErr
+	case resp.StatusCode < 400:
+		o.score = 1
+		o.bytes = resp.ContentLength
+		bucket = &c.successes
+	case resp.StatusCode == 429:
+		// Scored like a 5xx, not the general 4xx case below: a 429 means
+		// the peer itself is telling us to back off, same as main.canBackoff
+		// treating it as backoff-worthy rather than "usually our fault".
+		o.score = 0
+		bucket = &c.serverErr
+	case resp.StatusCode < 500:
+		o.score = 0.3
+		bucket = &c.clientErr
+	default:
+		o.score = 0
+		bucket = &c.serverErr
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*bucket++
+	c.window[c.cursor] = o
+	c.cursor = (c.cursor + 1) % scoreWindow
+	if c.filled < scoreWindow {
+		c.filled++
+	}
+}
+
+// Score reports the client's rolling health score in [0, 1], averaged over
+// its last [scoreWindow] requests. A client with no history yet is assumed
+// healthy, so it gets a chance to prove itself.
+func (c *Client) Score() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.filled == 0 {
+		return 1
+	}
+
+	var total float64
+	for i := 0; i < c.filled; i++ {
+		total += c.window[i].score
+	}
+	return total / float64(c.filled)
+}
+
+// Counters reports lifetime counts of successes (2xx/3xx), client errors
+// (4xx other than 429) and server errors (5xx, 429 and network errors), and
+// the average latency over the current rolling window, for introspection
+// over an admin endpoint.
+func (c *Client) Counters() (successes, clientErrors, serverErrors uint64, avgLatency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total time.Duration
+	for i := 0; i < c.filled; i++ {
+		total += c.window[i].latency
+	}
+	if c.filled > 0 {
+		avgLatency = total / time.Duration(c.filled)
+	}
+	return c.successes, c.clientErr, c.serverErr, avgLatency
 }
 
 func (c *Client) Close() {
@@ -42,6 +149,8 @@ func (c *Client) Close() {
 
 type Pool struct {
 	clients []*Client
+
+	mu      sync.Mutex
 	backoff []time.Time
 	last    int
 }
@@ -63,29 +172,122 @@ func (p *Pool) Close() {
 	}
 }
 
+// Select returns the client with the highest health score among those not
+// currently backed off, with epsilon-greedy exploration so a punished
+// client is periodically retried. If every client is backed off, all of
+// them become candidates again - something has to try.
 func (p *Pool) Select() *Client {
-	// Short-circuit when there's only one client
 	if len(p.clients) <= 1 {
+		p.mu.Lock()
+		p.last = 0
+		p.mu.Unlock()
 		return p.clients[0]
 	}
 
-	// Try a couple of times to select a non-backoffed client
+	p.mu.Lock()
 	now := time.Now()
-	for try := 0; try < len(p.clients); try++ {
-		idx := rand.IntN(len(p.clients))
-		if now.After(p.backoff[idx]) {
-			p.last = idx
-			return p.clients[idx]
+	var candidates []int
+	for i := range p.clients {
+		if now.After(p.backoff[i]) {
+			candidates = append(candidates, i)
+		}
+	}
+	p.mu.Unlock()
+	if len(candidates) == 0 {
+		for i := range p.clients {
+			candidates = append(candidates, i)
 		}
 	}
 
-	// Failed to do so - pick a random one
-	slog.Warn("Failed to select a non-backoffed client for the request")
-	idx := rand.IntN(len(p.clients))
+	var idx int
+	if rand.Float64() < explorationEpsilon {
+		idx = candidates[rand.IntN(len(candidates))]
+	} else {
+		idx = p.bestOf(candidates)
+	}
+
+	p.mu.Lock()
 	p.last = idx
+	p.mu.Unlock()
 	return p.clients[idx]
 }
 
+func (p *Pool) bestOf(candidates []int) int {
+	best := candidates[0]
+	bestScore := p.clients[best].Score()
+	for _, idx := range candidates[1:] {
+		if score := p.clients[idx].Score(); score > bestScore {
+			best, bestScore = idx, score
+		}
+	}
+	return best
+}
+
 func (p *Pool) BackoffLast() {
-	p.backoff[p.last] = time.Now().Add(PoolClientBackoff)
+	p.mu.Lock()
+	last := p.last
+	p.mu.Unlock()
+	p.backoffIndex(last)
+}
+
+// backoffIndex puts the client at idx into backoff for [PoolClientBackoff],
+// shared by BackoffLast (a failed real request) and probeOnce (a failed
+// background health check).
+func (p *Pool) backoffIndex(idx int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backoff[idx] = time.Now().Add(PoolClientBackoff)
+}
+
+// unblock clears a client's backoff ahead of schedule, called when a
+// background probe (see probe.go) finds it healthy again.
+func (p *Pool) unblock(idx int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backoff[idx] = time.Time{}
+}
+
+// isBackedOff reports whether idx is currently serving its backoff period.
+func (p *Pool) isBackedOff(idx int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Now().Before(p.backoff[idx])
+}
+
+// Status summarizes one pool member, for reporting over an admin/metrics
+// endpoint.
+type Status struct {
+	Label          string        `json:"label"`
+	Score          float64       `json:"score"`
+	Successes      uint64        `json:"successes"`
+	ClientErrors   uint64        `json:"client_errors"`
+	ServerErrors   uint64        `json:"server_errors"`
+	AverageLatency time.Duration `json:"average_latency"`
+	BackoffUntil   time.Time     `json:"backoff_until,omitempty"`
+	LastSelected   bool          `json:"last_selected"`
+}
+
+// Status reports the health and backoff state of every client in the pool.
+func (p *Pool) Status() []Status {
+	p.mu.Lock()
+	backoff := make([]time.Time, len(p.backoff))
+	copy(backoff, p.backoff)
+	last := p.last
+	p.mu.Unlock()
+
+	out := make([]Status, len(p.clients))
+	for i, c := range p.clients {
+		successes, clientErrors, serverErrors, avgLatency := c.Counters()
+		out[i] = Status{
+			Label:          c.Label,
+			Score:          c.Score(),
+			Successes:      successes,
+			ClientErrors:   clientErrors,
+			ServerErrors:   serverErrors,
+			AverageLatency: avgLatency,
+			BackoffUntil:   backoff[i],
+			LastSelected:   i == last,
+		}
+	}
+	return out
 }
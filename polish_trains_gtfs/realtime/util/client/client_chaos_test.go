@@ -0,0 +1,58 @@
+//go:build chaos
+
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
+)
+
+// TestPool_Select_StopsReturningClientAfterRepeated503s wires
+// [http2.FaultInjectingDoer] into a real [Pool], the same way --simulate-failures
+// wires it into clientPool in cmd/main, to cover the path client_test.go's
+// fixedDoer-based tests don't: Select actually excluding a peer once repeated
+// failures from the same VPN peer have tanked its score and it's been backed off.
+func TestPool_Select_StopsReturningClientAfterRepeated503s(t *testing.T) {
+	faulty := &Client{Doer: &http2.FaultInjectingDoer{
+		Parent:  fixedDoer{200},
+		Profile: http2.FaultProfile{Rate503: 1},
+		Rand:    rand.New(rand.NewPCG(1, 1)), // seeded: deterministic
+	}}
+	healthy := &Client{Doer: fixedDoer{200}}
+	p := NewPool(faulty, healthy)
+
+	// Drive enough consecutive 503s through the faulty client to tank its
+	// score, the same way main.fetch does before main.canBackoff triggers
+	// clientPool.BackoffLast().
+	for range scoreWindow {
+		resp, err := faulty.Do(newReq(t))
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if resp.StatusCode != 503 {
+			t.Fatalf("StatusCode = %d, want 503", resp.StatusCode)
+		}
+	}
+
+	p.last = 0
+	p.BackoffLast()
+
+	for range 20 {
+		if got := p.Select(); got == faulty {
+			t.Fatal("Select() returned the client backed off after repeated 503s")
+		}
+	}
+
+	if !p.isBackedOff(0) {
+		t.Fatal("faulty client should still be backed off right after BackoffLast")
+	}
+	if wantNotBefore := time.Now().Add(PoolClientBackoff - time.Second); p.backoff[0].Before(wantNotBefore) {
+		t.Fatalf("backoff[0] = %v, want at least PoolClientBackoff (%s) out", p.backoff[0], PoolClientBackoff)
+	}
+}
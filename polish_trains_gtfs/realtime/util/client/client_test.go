@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fixedDoer always returns the given status code.
+type fixedDoer struct{ status int }
+
+func (d fixedDoer) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: d.status, Body: http.NoBody, Request: req}, nil
+}
+
+func newReq(t *testing.T) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("GET", "https://example.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	return req
+}
+
+func TestClient_Score(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   float64
+	}{
+		{"healthy", 200, 1},
+		{"client error", 404, 0.3},
+		{"rate limited", 429, 0},
+		{"server error", 503, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{Doer: fixedDoer{tt.status}}
+			for range 5 {
+				if _, err := c.Do(newReq(t)); err != nil {
+					t.Fatalf("Do: %v", err)
+				}
+			}
+			if got := c.Score(); got != tt.want {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Score_NoHistoryIsOptimistic(t *testing.T) {
+	c := &Client{Doer: fixedDoer{200}}
+	if got := c.Score(); got != 1 {
+		t.Errorf("Score() with no history = %v, want 1", got)
+	}
+}
+
+func TestPool_Select_AvoidsBackedOffClient(t *testing.T) {
+	healthy := &Client{Doer: fixedDoer{200}}
+	unhealthy := &Client{Doer: fixedDoer{503}}
+	p := NewPool(unhealthy, healthy)
+
+	// Drive the unhealthy client into a bad score and then back it off,
+	// simulating what main.go does after a failed run.
+	for range 10 {
+		unhealthy.Do(newReq(t))
+	}
+	p.last = 0
+	p.BackoffLast()
+
+	for range 20 {
+		if got := p.Select(); got == unhealthy {
+			// epsilon-greedy exploration is allowed to pick it, but it
+			// must still be gated by the backoff, so this should never
+			// happen while backoff is outstanding below the cap.
+			t.Fatalf("Select() returned the backed-off client")
+		}
+	}
+}
+
+func TestPool_IsBackedOff_FalseOnceBackoffExpired(t *testing.T) {
+	a := &Client{Doer: fixedDoer{200}}
+	b := &Client{Doer: fixedDoer{200}}
+	p := NewPool(a, b)
+
+	p.last = 0
+	p.backoff[0] = time.Now().Add(-time.Second) // already expired
+	if p.isBackedOff(0) {
+		t.Fatal("isBackedOff should be false once the backoff window has passed")
+	}
+}
+
+func TestPool_Select_SingleClientShortCircuits(t *testing.T) {
+	only := &Client{Doer: fixedDoer{200}}
+	p := NewPool(only)
+	if got := p.Select(); got != only {
+		t.Fatalf("Select() = %v, want the only client", got)
+	}
+}
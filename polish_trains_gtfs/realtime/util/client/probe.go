@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultProbeInterval is how often StartProbing issues its health checks.
+const DefaultProbeInterval = 30 * time.Second
+
+// StartProbing launches one background goroutine per client that issues a
+// lightweight HEAD request to url every interval, independent of real
+// traffic. This is how a dead WireGuard peer is noticed before a real
+// request pays the cost of trying it, and how a client's backoff gets
+// cleared early if it turns out to have recovered. Stops when ctx is
+// cancelled.
+func (p *Pool) StartProbing(ctx context.Context, url string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+
+	for i, c := range p.clients {
+		go p.probeLoop(ctx, i, c, url, interval)
+	}
+}
+
+func (p *Pool) probeLoop(ctx context.Context, idx int, c *Client, url string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.probeOnce(idx, c, url)
+		}
+	}
+}
+
+func (p *Pool) probeOnce(idx int, c *Client, url string) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return
+	}
+	if c.Key != "" {
+		req.Header.Set("X-Api-Key", c.Key)
+	}
+
+	resp, err := c.Doer.Do(req)
+	// A 429 isn't a 5xx, but it's still the peer telling us to back off -
+	// same classification as Client.record and main.canBackoff use for it.
+	healthy := err == nil && resp.StatusCode < 500 && resp.StatusCode != 429
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	if healthy {
+		if p.isBackedOff(idx) {
+			p.unblock(idx)
+		}
+	} else {
+		// Catch a dead or newly-unhealthy peer before a real request pays
+		// the cost of trying it, not just clear backoffs early.
+		p.backoffIndex(idx)
+	}
+}
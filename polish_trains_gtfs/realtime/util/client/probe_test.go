@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package client
+
+import "testing"
+
+func TestPool_ProbeOnce_BacksOffFailingPeer(t *testing.T) {
+	c := &Client{Doer: fixedDoer{503}}
+	p := NewPool(c, &Client{Doer: fixedDoer{200}})
+
+	p.probeOnce(0, c, "https://example.com/")
+
+	if !p.isBackedOff(0) {
+		t.Fatal("a failed probe should have backed the peer off")
+	}
+}
+
+func TestPool_ProbeOnce_TreatsRateLimitedAsUnhealthy(t *testing.T) {
+	c := &Client{Doer: fixedDoer{429}}
+	p := NewPool(c, &Client{Doer: fixedDoer{200}})
+
+	p.probeOnce(0, c, "https://example.com/")
+
+	if !p.isBackedOff(0) {
+		t.Fatal("a 429 probe response should be treated as unhealthy, not cleared")
+	}
+}
+
+func TestPool_ProbeOnce_UnblocksRecoveredPeer(t *testing.T) {
+	c := &Client{Doer: fixedDoer{200}}
+	p := NewPool(c, &Client{Doer: fixedDoer{200}})
+	p.backoffIndex(0)
+
+	p.probeOnce(0, c, "https://example.com/")
+
+	if p.isBackedOff(0) {
+		t.Fatal("a healthy probe response should have cleared the backoff")
+	}
+}
@@ -0,0 +1,92 @@
+//go:build chaos
+
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package http2
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FaultProfile configures how often [FaultInjectingDoer] injects each kind
+// of failure. Rates are per-request probabilities in [0, 1]. Rate429, Rate500
+// and Rate503 share a single roll, so at most one status is injected per
+// request and each rate holds as given only while their sum stays within 1;
+// DropRate is rolled separately.
+type FaultProfile struct {
+	Rate429, Rate500, Rate503 float64
+	DropRate                  float64       // probability of truncating the response body mid-read
+	MaxExtraLatency           time.Duration // upper bound of uniformly random extra latency per request
+	RetryAfter                time.Duration // set as the Retry-After header on injected 429s
+}
+
+// FaultInjectingDoer decorates another [Doer] and, according to Profile,
+// randomly causes requests to fail the same way the real PKP API
+// occasionally does (429/500/503, dropped bodies, extra latency). It only
+// exists in builds tagged "chaos" so it can never ship in a production
+// binary by accident; see --simulate-failures in cmd/main for how it's
+// wired up. Rand lets tests get deterministic, seed-controlled behavior;
+// a nil Rand falls back to the global source.
+type FaultInjectingDoer struct {
+	Parent  Doer
+	Profile FaultProfile
+	Rand    *rand.Rand
+}
+
+func (d *FaultInjectingDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.Profile.MaxExtraLatency > 0 {
+		time.Sleep(time.Duration(d.float64() * float64(d.Profile.MaxExtraLatency)))
+	}
+
+	if status, ok := d.injectedStatus(); ok {
+		return d.syntheticResponse(req, status), nil
+	}
+
+	resp, err := d.Parent.Do(req)
+	if err == nil && d.float64() < d.Profile.DropRate {
+		resp.Body = io.NopCloser(io.LimitReader(resp.Body, 1))
+	}
+	return resp, err
+}
+
+func (d *FaultInjectingDoer) injectedStatus() (int, bool) {
+	roll := d.float64()
+	switch {
+	case roll < d.Profile.Rate429:
+		return 429, true
+	case roll < d.Profile.Rate429+d.Profile.Rate500:
+		return 500, true
+	case roll < d.Profile.Rate429+d.Profile.Rate500+d.Profile.Rate503:
+		return 503, true
+	default:
+		return 0, false
+	}
+}
+
+func (d *FaultInjectingDoer) syntheticResponse(req *http.Request, status int) *http.Response {
+	header := make(http.Header)
+	if status == 429 && d.Profile.RetryAfter > 0 {
+		header.Set("Retry-After", strconv.Itoa(int(d.Profile.RetryAfter.Seconds())))
+	}
+
+	return &http.Response{
+		Status:     strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}
+}
+
+func (d *FaultInjectingDoer) float64() float64 {
+	if d.Rand != nil {
+		return d.Rand.Float64()
+	}
+	return rand.Float64()
+}
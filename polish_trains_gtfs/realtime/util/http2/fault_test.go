@@ -0,0 +1,90 @@
+//go:build chaos
+
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package http2
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type okDoer struct{}
+
+func (okDoer) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: 200, Body: http.NoBody, Request: req}, nil
+}
+
+func TestFaultInjectingDoer_AlwaysInjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile FaultProfile
+		want    int
+	}{
+		{"429", FaultProfile{Rate429: 1, RetryAfter: 5 * time.Second}, 429},
+		{"500", FaultProfile{Rate500: 1}, 500},
+		{"503", FaultProfile{Rate503: 1}, 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &FaultInjectingDoer{
+				Parent:  okDoer{},
+				Profile: tt.profile,
+				Rand:    rand.New(rand.NewPCG(1, 1)), // seeded: deterministic
+			}
+
+			req, _ := http.NewRequest("GET", "https://example.com/", nil)
+			resp, err := d.Do(req)
+			if err != nil {
+				t.Fatalf("Do: unexpected error: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("StatusCode = %d, want %d", resp.StatusCode, tt.want)
+			}
+
+			if err := Check(resp); err == nil {
+				t.Fatal("Check: expected an error for a failing status code")
+			} else if httpErr, ok := err.(*Error); !ok || httpErr.StatusCode != tt.want {
+				t.Fatalf("Check: got %#v, want *Error with StatusCode %d", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFaultInjectingDoer_RetryAfterHeader(t *testing.T) {
+	d := &FaultInjectingDoer{
+		Parent:  okDoer{},
+		Profile: FaultProfile{Rate429: 1, RetryAfter: 7 * time.Second},
+		Rand:    rand.New(rand.NewPCG(1, 1)),
+	}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	resp, err := d.Do(req)
+	if err != nil {
+		t.Fatalf("Do: unexpected error: %v", err)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "7" {
+		t.Fatalf("Retry-After = %q, want %q", got, "7")
+	}
+}
+
+func TestFaultInjectingDoer_NeverInjects(t *testing.T) {
+	d := &FaultInjectingDoer{
+		Parent:  okDoer{},
+		Profile: FaultProfile{}, // all rates zero
+		Rand:    rand.New(rand.NewPCG(1, 1)),
+	}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	resp, err := d.Do(req)
+	if err != nil {
+		t.Fatalf("Do: unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+}
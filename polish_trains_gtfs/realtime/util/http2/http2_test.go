@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package http2
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// scriptedDoer returns one canned response per call, in order, and fails
+// the test if called more times than scripted.
+type scriptedDoer struct {
+	t         *testing.T
+	responses []func(*http.Request) (*http.Response, error)
+	calls     int
+}
+
+func (d *scriptedDoer) Do(req *http.Request) (*http.Response, error) {
+	d.t.Helper()
+	if d.calls >= len(d.responses) {
+		d.t.Fatalf("Do called %d times, only %d responses scripted", d.calls+1, len(d.responses))
+	}
+	resp, err := d.responses[d.calls](req)
+	d.calls++
+	return resp, err
+}
+
+func jsonResponse(req *http.Request, status int, body string, headers map[string]string) *http.Response {
+	h := make(http.Header)
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     strconv.Itoa(status),
+		Header:     h,
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Request:    req,
+	}
+}
+
+func TestGetJSON_NoPolicy_FailsOnFirstError(t *testing.T) {
+	d := &scriptedDoer{t: t, responses: []func(*http.Request) (*http.Response, error){
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 503, "", nil), nil },
+	}}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	_, err := GetJSON[struct{}](d, req)
+	if err == nil {
+		t.Fatal("expected an error without a retry policy")
+	}
+	if d.calls != 1 {
+		t.Fatalf("calls = %d, want 1 (no retries without a policy)", d.calls)
+	}
+}
+
+func TestGetJSON_FallsBackToDefaultRetry(t *testing.T) {
+	old := DefaultRetry
+	DefaultRetry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	defer func() { DefaultRetry = old }()
+
+	d := &scriptedDoer{t: t, responses: []func(*http.Request) (*http.Response, error){
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 503, "", nil), nil },
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 200, `{}`, nil), nil },
+	}}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	_, err := GetJSON[struct{}](d, req) // no explicit policy: must pick up DefaultRetry
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.calls != 2 {
+		t.Fatalf("calls = %d, want 2 (DefaultRetry should have covered the 503)", d.calls)
+	}
+}
+
+func TestGetJSON_RetriesUntilSuccess(t *testing.T) {
+	d := &scriptedDoer{t: t, responses: []func(*http.Request) (*http.Response, error){
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 503, "", nil), nil },
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 429, "", nil), nil },
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 200, `{}`, nil), nil },
+	}}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	_, err := GetJSON[struct{}](d, req, policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.calls != 3 {
+		t.Fatalf("calls = %d, want 3", d.calls)
+	}
+}
+
+func TestGetJSON_GivesUpAfterMaxAttempts(t *testing.T) {
+	d := &scriptedDoer{t: t, responses: []func(*http.Request) (*http.Response, error){
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 503, "", nil), nil },
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 503, "", nil), nil },
+	}}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	_, err := GetJSON[struct{}](d, req, policy)
+	if err == nil {
+		t.Fatal("expected an error after exhausting attempts")
+	}
+	if d.calls != 2 {
+		t.Fatalf("calls = %d, want 2", d.calls)
+	}
+}
+
+func TestGetJSON_NonRetryableErrorStopsImmediately(t *testing.T) {
+	d := &scriptedDoer{t: t, responses: []func(*http.Request) (*http.Response, error){
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 404, "", nil), nil },
+	}}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+	_, err := GetJSON[struct{}](d, req, policy)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if d.calls != 1 {
+		t.Fatalf("calls = %d, want 1 (404 is not retryable)", d.calls)
+	}
+}
+
+func TestGetJSON_RespectsRetryAfterHeader(t *testing.T) {
+	d := &scriptedDoer{t: t, responses: []func(*http.Request) (*http.Response, error){
+		func(r *http.Request) (*http.Response, error) {
+			return jsonResponse(r, 429, "", map[string]string{"Retry-After": "1"}), nil
+		},
+		func(r *http.Request) (*http.Response, error) { return jsonResponse(r, 200, `{}`, nil), nil },
+	}}
+
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour} // huge base: if ignored, the test would hang
+
+	start := time.Now()
+	_, err := GetJSON[struct{}](d, req, policy)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("took %s, Retry-After: 1 should have overridden the exponential delay", elapsed)
+	}
+}